@@ -0,0 +1,171 @@
+package snappy
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+)
+
+// indexEntryLen is the marshaled size, in bytes, of a single indexEntry.
+const indexEntryLen = 24
+
+// indexEntry records where one hadoop-snappy frame begins, in both the
+// uncompressed and compressed address spaces, and how many uncompressed
+// bytes it holds.
+type indexEntry struct {
+	uncompressedOffset int64
+	compressedOffset   int64
+	uncompressedLen    int64
+}
+
+// Index is a sidecar index over a hadoop-snappy stream's frame boundaries,
+// built by BuildIndex, that makes random access to that stream efficient
+// via SeekableReader without decompressing data the caller doesn't need.
+type Index struct {
+	entries []indexEntry
+}
+
+// BuildIndex walks the frame headers of the hadoop-snappy stream read from
+// r, recording the uncompressed and compressed offset of each frame and its
+// uncompressed length. It reads just enough of each block to determine its
+// decompressed length, via the snappy preamble, and never decompresses a
+// block body.
+func BuildIndex(r io.ReaderAt) (*Index, error) {
+	var (
+		entries            []indexEntry
+		compressedOffset   int64
+		uncompressedOffset int64
+	)
+
+	for {
+		frameUncompressedSize, err := readHeaderAt(r, compressedOffset)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("hadoop-snappy: build index: read frame header at %d: %w", compressedOffset, err)
+		}
+
+		entries = append(entries, indexEntry{
+			uncompressedOffset: uncompressedOffset,
+			compressedOffset:   compressedOffset,
+			uncompressedLen:    int64(frameUncompressedSize),
+		})
+
+		pos := compressedOffset + headerLength
+		frameRemaining := frameUncompressedSize
+
+		for frameRemaining > 0 {
+			blockLength, err := readHeaderAt(r, pos)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+
+				return nil, fmt.Errorf("hadoop-snappy: build index: read block header at %d: %w", pos, err)
+			}
+			pos += headerLength
+
+			if blockLength == 0 {
+				return nil, fmt.Errorf("hadoop-snappy: build index: %w", errEmptyBlock)
+			}
+
+			preamble := make([]byte, binary.MaxVarintLen64)
+			if blockLength < len(preamble) {
+				preamble = preamble[:blockLength]
+			}
+
+			n, err := r.ReadAt(preamble, pos)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("hadoop-snappy: build index: read block preamble at %d: %w", pos, err)
+			}
+
+			decompressedLength, err := snappy.DecodedLen(preamble[:n])
+			if err != nil {
+				return nil, fmt.Errorf("hadoop-snappy: build index: determine block decoded length at %d: %w", pos, err)
+			}
+
+			if decompressedLength > frameRemaining {
+				return nil, fmt.Errorf("hadoop-snappy: build index: %w", errDecompressedTooLarge)
+			}
+
+			frameRemaining -= decompressedLength
+			pos += int64(blockLength)
+		}
+
+		uncompressedOffset += int64(frameUncompressedSize)
+		compressedOffset = pos
+	}
+
+	return &Index{entries: entries}, nil
+}
+
+// MarshalBinary encodes the index as a sequence of fixed-width big-endian
+// fields, suitable for persisting next to the stream it indexes.
+func (idx *Index) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 8+len(idx.entries)*indexEntryLen)
+
+	binary.BigEndian.PutUint64(buf, uint64(len(idx.entries)))
+
+	for i, e := range idx.entries {
+		off := 8 + i*indexEntryLen
+		binary.BigEndian.PutUint64(buf[off:], uint64(e.uncompressedOffset))
+		binary.BigEndian.PutUint64(buf[off+8:], uint64(e.compressedOffset))
+		binary.BigEndian.PutUint64(buf[off+16:], uint64(e.uncompressedLen))
+	}
+
+	return buf, nil
+}
+
+// UnmarshalBinary decodes an index previously produced by MarshalBinary.
+func (idx *Index) UnmarshalBinary(data []byte) error {
+	if len(data) < 8 {
+		return fmt.Errorf("hadoop-snappy: unmarshal index: %w", io.ErrUnexpectedEOF)
+	}
+
+	count := binary.BigEndian.Uint64(data)
+
+	// Bound count against the data actually available before trusting it
+	// for allocation or arithmetic: this index is meant to accompany
+	// untrusted, HDFS-adjacent streams (see BuildIndex), and an attacker- or
+	// corruption-controlled count could otherwise overflow want or drive
+	// make into an enormous or panicking allocation.
+	maxCount := uint64(len(data)-8) / indexEntryLen
+	if count > maxCount {
+		return fmt.Errorf("hadoop-snappy: unmarshal index: count %d exceeds %d bytes of entry data", count, len(data)-8)
+	}
+
+	want := 8 + int(count)*indexEntryLen
+	if len(data) != want {
+		return fmt.Errorf("hadoop-snappy: unmarshal index: got %d bytes, want %d", len(data), want)
+	}
+
+	entries := make([]indexEntry, count)
+	for i := range entries {
+		off := 8 + i*indexEntryLen
+		entries[i] = indexEntry{
+			uncompressedOffset: int64(binary.BigEndian.Uint64(data[off:])),
+			compressedOffset:   int64(binary.BigEndian.Uint64(data[off+8:])),
+			uncompressedLen:    int64(binary.BigEndian.Uint64(data[off+16:])),
+		}
+	}
+
+	idx.entries = entries
+
+	return nil
+}
+
+func readHeaderAt(r io.ReaderAt, offset int64) (int, error) {
+	headerBuf := make([]byte, headerLength)
+
+	_, err := r.ReadAt(headerBuf, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(binary.BigEndian.Uint32(headerBuf)), nil
+}
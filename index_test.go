@@ -0,0 +1,118 @@
+package snappy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBuildIndex(t *testing.T) {
+	input := mustReadFile("testdata/shakespeare.txt")
+
+	var compressed bytes.Buffer
+	w := NewWriterSize(&compressed, 4096, 1024)
+	if _, err := w.Write(input); err != nil {
+		t.Fatalf("unexpected error writing input: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	data := compressed.Bytes()
+
+	idx, err := BuildIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error building index: %v", err)
+	}
+
+	if len(idx.entries) == 0 {
+		t.Fatalf("expected at least one index entry")
+	}
+
+	// Every entry's uncompressed offset and length should agree with
+	// decompressing the stream serially, and every compressed offset
+	// should point at a valid frame header.
+	var uncompressedOffset int64
+	for i, e := range idx.entries {
+		if e.uncompressedOffset != uncompressedOffset {
+			t.Fatalf("entry %d: uncompressedOffset = %d, want %d", i, e.uncompressedOffset, uncompressedOffset)
+		}
+
+		frameSize, err := readHeaderAt(bytes.NewReader(data), e.compressedOffset)
+		if err != nil {
+			t.Fatalf("entry %d: could not read frame header at compressed offset %d: %v", i, e.compressedOffset, err)
+		}
+		if int64(frameSize) != e.uncompressedLen {
+			t.Fatalf("entry %d: frame header says %d, index says %d", i, frameSize, e.uncompressedLen)
+		}
+
+		uncompressedOffset += e.uncompressedLen
+	}
+
+	if uncompressedOffset != int64(len(input)) {
+		t.Errorf("index covers %d uncompressed bytes, want %d", uncompressedOffset, len(input))
+	}
+}
+
+// TestBuildIndexRejectsOversizedDecompressedBlock confirms that BuildIndex
+// rejects a stream where a block's snappy preamble overstates its decoded
+// length relative to the frame header, the same corruption Reader.decompress
+// rejects with errDecompressedTooLarge, instead of silently recording a
+// bogus index entry.
+func TestBuildIndexRejectsOversizedDecompressedBlock(t *testing.T) {
+	data := mustReadFile("testdata/test.jsonl.snappy")
+	// data[3] is the least significant byte of the frame header, which is
+	// 0x40 (64) in the unaltered file; decreasing it understates the frame's
+	// uncompressed size relative to what the block actually decodes to, the
+	// same corruption TestSnappyReader uses to trigger errDecompressedTooLarge.
+	data[3] -= 1
+
+	_, err := BuildIndex(bytes.NewReader(data))
+	if !errors.Is(err, errDecompressedTooLarge) {
+		t.Fatalf("got error %v, want errDecompressedTooLarge", err)
+	}
+}
+
+func TestIndexMarshalRoundTrip(t *testing.T) {
+	input := mustReadFile("testdata/shakespeare.txt")
+
+	var compressed bytes.Buffer
+	w := NewWriterSize(&compressed, 4096, 1024)
+	if _, err := w.Write(input); err != nil {
+		t.Fatalf("unexpected error writing input: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	idx, err := BuildIndex(bytes.NewReader(compressed.Bytes()))
+	if err != nil {
+		t.Fatalf("unexpected error building index: %v", err)
+	}
+
+	marshaled, err := idx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error marshaling index: %v", err)
+	}
+
+	var roundTripped Index
+	if err := roundTripped.UnmarshalBinary(marshaled); err != nil {
+		t.Fatalf("unexpected error unmarshaling index: %v", err)
+	}
+
+	if !reflect.DeepEqual(idx.entries, roundTripped.entries) {
+		t.Errorf("round-tripped index does not match original")
+	}
+}
+
+func TestIndexUnmarshalRejectsOversizedCount(t *testing.T) {
+	data := make([]byte, 8+indexEntryLen)
+	binary.BigEndian.PutUint64(data, 1<<40)
+
+	var idx Index
+	if err := idx.UnmarshalBinary(data); err == nil {
+		t.Fatalf("expected error unmarshaling index with a count unsupported by the data length, got nil")
+	}
+}
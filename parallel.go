@@ -0,0 +1,427 @@
+package snappy
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+)
+
+// defaultConcurrency is used by NewParallelReader when ReaderConcurrency is
+// not supplied.
+const defaultConcurrency = 4
+
+// defaultMaxInFlightBytes bounds the total size of blocks that have been
+// read from the input but not yet decompressed, counting each block at the
+// larger of its compressed and decompressed sizes, so a handful of large
+// blocks can't exhaust memory even though each is individually within
+// maxBlockSize. A block's bytes are released as soon as a worker has
+// decompressed it; decompressed blocks waiting to be reordered or read are
+// not counted against this bound.
+const defaultMaxInFlightBytes = 16 * 1024 * 1024
+
+// ReaderConcurrency sets the number of worker goroutines a ParallelReader
+// uses to decompress blocks. It has no effect on a serial Reader. The
+// default, used when this option is not supplied, is defaultConcurrency.
+func ReaderConcurrency(n int) ReaderOption {
+	return func(c *readerConfig) {
+		c.concurrency = n
+	}
+}
+
+// parallelJob is a block of compressed bytes awaiting decompression, in
+// stream order.
+type parallelJob struct {
+	seq             int
+	compressed      []byte
+	decompressedLen int
+}
+
+// parallelResult is the decompressed form of a parallelJob, or a terminal
+// error or io.EOF encountered while producing or decoding it. Results are
+// always delivered to the consumer in seq order.
+type parallelResult struct {
+	seq  int
+	data []byte
+	err  error
+}
+
+// resultHeap orders parallelResults by seq so the collector can re-establish
+// stream order from workers that finish out of order.
+type resultHeap []parallelResult
+
+func (h resultHeap) Len() int            { return len(h) }
+func (h resultHeap) Less(i, j int) bool  { return h[i].seq < h[j].seq }
+func (h resultHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *resultHeap) Push(x interface{}) { *h = append(*h, x.(parallelResult)) }
+func (h *resultHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// ParallelReader decompresses a hadoop-snappy stream the same way Reader
+// does, but fans the CPU-bound decompression of individual blocks out
+// across a pool of worker goroutines while preserving the original block
+// order of the decompressed output.
+type ParallelReader struct {
+	in  io.Reader
+	cfg readerConfig
+
+	currentBlock []byte
+
+	done     chan struct{}
+	doneOnce sync.Once
+	ordered  chan parallelResult
+
+	startOnce sync.Once
+	nextSeq   int
+
+	// terminalErr caches the first io.EOF or error delivered on r.ordered,
+	// so that Read keeps returning it on every subsequent call instead of
+	// io.ErrClosedPipe once r.ordered has been drained and closed.
+	terminalErr error
+}
+
+// NewParallelReader returns a ParallelReader that decompresses the
+// hadoop-snappy stream read from in, using up to cfg.concurrency worker
+// goroutines (see ReaderConcurrency) to decompress blocks concurrently.
+// Aside from its concurrency, it honors ReaderMaxFrameSize and
+// ReaderMaxBlockSize, and returns the same decompressed bytes in the same
+// order, as NewReader. ReaderBufferPool is accepted but has no effect: with
+// many blocks decompressing concurrently, a single shared buffer can't be
+// reused the way a serial Reader reuses one, so every block's buffers are
+// allocated fresh.
+func NewParallelReader(in io.Reader, opts ...ReaderOption) *ParallelReader {
+	cfg := newReaderConfig(opts)
+	if cfg.concurrency < 1 {
+		cfg.concurrency = defaultConcurrency
+	}
+
+	return &ParallelReader{
+		in:      in,
+		cfg:     cfg,
+		done:    make(chan struct{}),
+		ordered: make(chan parallelResult, cfg.concurrency),
+	}
+}
+
+// Read implements the io.Reader interface, returning decompressed blocks in
+// their original stream order regardless of which worker goroutine
+// decompressed them.
+func (r *ParallelReader) Read(out []byte) (int, error) {
+	if r.terminalErr != nil {
+		return 0, r.terminalErr
+	}
+
+	r.startOnce.Do(r.start)
+
+	for len(r.currentBlock) == 0 {
+		result, ok := <-r.ordered
+		if !ok {
+			// A terminal result is cached in terminalErr and returned before
+			// r.ordered is read again, so r.ordered is only seen closed here
+			// when Close stopped collect before the stream ended.
+			if r.terminalErr == nil {
+				r.terminalErr = io.ErrClosedPipe
+			}
+			return 0, r.terminalErr
+		}
+
+		if result.err != nil {
+			// Cache the terminal result so every Read call after the stream
+			// has ended keeps returning it, the same way a serial Reader
+			// keeps returning io.EOF, instead of io.ErrClosedPipe once
+			// r.ordered is drained and closed.
+			r.terminalErr = result.err
+
+			// Self-cancel on a terminal result so a caller that stops reading
+			// after this error (the idiomatic io.Reader contract) without
+			// also calling Close doesn't leak the producer and worker
+			// goroutines parked forever on jobs<-/results<- sends.
+			r.Close()
+			return 0, result.err
+		}
+
+		r.currentBlock = result.data
+	}
+
+	n := copy(out, r.currentBlock)
+	r.currentBlock = r.currentBlock[n:]
+
+	return n, nil
+}
+
+// Close stops any in-flight workers and the producer goroutine. It is safe
+// to call Close before the stream has been fully read.
+func (r *ParallelReader) Close() error {
+	r.doneOnce.Do(func() {
+		close(r.done)
+	})
+
+	return nil
+}
+
+// start launches the producer, worker pool, and collector goroutines that
+// back Read. It runs once, lazily, so constructing a ParallelReader has no
+// side effects until the caller actually reads from it.
+func (r *ParallelReader) start() {
+	jobs := make(chan parallelJob, r.cfg.concurrency)
+	results := make(chan parallelResult, r.cfg.concurrency)
+	sem := newByteSemaphore(defaultMaxInFlightBytes)
+
+	var workers sync.WaitGroup
+	workers.Add(r.cfg.concurrency)
+	for i := 0; i < r.cfg.concurrency; i++ {
+		go func() {
+			defer workers.Done()
+			r.work(jobs, results, sem)
+		}()
+	}
+
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	go r.produce(jobs, results, sem)
+	go r.collect(results)
+}
+
+// produce reads frame and block headers sequentially from the input
+// stream (as Reader does), validating them against r.cfg, and enqueues the
+// compressed bytes of each block as a job for the worker pool to
+// decompress. It stops at the first error or at a clean end of stream,
+// sending that outcome directly to results under the next seq, so collect
+// surfaces it to the caller in the correct stream position.
+func (r *ParallelReader) produce(jobs chan<- parallelJob, results chan<- parallelResult, sem *byteSemaphore) {
+	defer close(jobs)
+
+	seq := 0
+	frameRemaining := 0
+
+	for {
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+
+		if frameRemaining == 0 {
+			uncompressedSize, err := readHeader(r.in)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					r.sendResult(results, parallelResult{seq: seq, err: io.EOF})
+					return
+				}
+
+				r.sendResult(results, parallelResult{seq: seq, err: fmt.Errorf("hadoop-snappy: read frame header: %w", err)})
+				return
+			}
+
+			if uncompressedSize > r.cfg.maxFrameSize {
+				r.sendResult(results, parallelResult{seq: seq, err: fmt.Errorf("hadoop-snappy: frame header: %w", ErrFrameTooLarge)})
+				return
+			}
+
+			frameRemaining = uncompressedSize
+		}
+
+		nextBlockLength, err := readHeader(r.in)
+		if err != nil {
+			if errors.Is(err, io.EOF) && frameRemaining != 0 {
+				err = io.ErrUnexpectedEOF
+			}
+
+			r.sendResult(results, parallelResult{seq: seq, err: fmt.Errorf("hadoop-snappy: read block header: %w", err)})
+			return
+		}
+
+		if nextBlockLength == 0 && frameRemaining != 0 {
+			r.sendResult(results, parallelResult{seq: seq, err: errEmptyBlock})
+			return
+		}
+
+		if nextBlockLength > r.cfg.maxBlockSize {
+			r.sendResult(results, parallelResult{seq: seq, err: fmt.Errorf("hadoop-snappy: block header: %w", ErrBlockTooLarge)})
+			return
+		}
+
+		sem.acquire(int64(nextBlockLength))
+
+		compressed := make([]byte, nextBlockLength)
+		if _, err := io.ReadFull(r.in, compressed); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+
+			sem.release(int64(nextBlockLength))
+			r.sendResult(results, parallelResult{seq: seq, err: fmt.Errorf("hadoop-snappy: read block: %w", err)})
+			return
+		}
+
+		decompressedLength, err := snappy.DecodedLen(compressed)
+		if err != nil {
+			sem.release(int64(nextBlockLength))
+			r.sendResult(results, parallelResult{seq: seq, err: fmt.Errorf("hadoop-snappy: determine block decoded length: %w", err)})
+			return
+		}
+
+		if decompressedLength > frameRemaining {
+			sem.release(int64(nextBlockLength))
+			r.sendResult(results, parallelResult{seq: seq, err: fmt.Errorf("hadoop-snappy: decompress block: %w", errDecompressedTooLarge)})
+			return
+		}
+
+		// The semaphore was acquired for the compressed bytes only; once the
+		// decompressed length is known, re-acquire for whichever of the two
+		// is larger in a single call, so the zero-in-flight escape hatch in
+		// acquire still applies to oversized blocks. Acquiring the
+		// difference in a second, separate call would leave cur > 0 from the
+		// first call and defeat that escape hatch, deadlocking on any block
+		// whose decompressed size alone exceeds the in-flight cap.
+		if decompressedLength > nextBlockLength {
+			sem.release(int64(nextBlockLength))
+			sem.acquire(int64(decompressedLength))
+		}
+
+		frameRemaining -= decompressedLength
+
+		select {
+		case jobs <- parallelJob{seq: seq, compressed: compressed, decompressedLen: decompressedLength}:
+		case <-r.done:
+			return
+		}
+
+		seq++
+	}
+}
+
+// sendResult delivers res to results, unless Close is called first.
+func (r *ParallelReader) sendResult(results chan<- parallelResult, res parallelResult) {
+	select {
+	case results <- res:
+	case <-r.done:
+	}
+}
+
+// work decompresses jobs until the jobs channel is closed or Close is
+// called, sending each decompressed block (or decode error) to results.
+func (r *ParallelReader) work(jobs <-chan parallelJob, results chan<- parallelResult, sem *byteSemaphore) {
+	for {
+		select {
+		case job, ok := <-jobs:
+			if !ok {
+				return
+			}
+
+			data := make([]byte, job.decompressedLen)
+			decompressed, err := snappy.Decode(data, job.compressed)
+
+			inFlight := int64(len(job.compressed))
+			if job.decompressedLen > len(job.compressed) {
+				inFlight = int64(job.decompressedLen)
+			}
+			sem.release(inFlight)
+
+			result := parallelResult{seq: job.seq, data: decompressed}
+			if err != nil {
+				result = parallelResult{seq: job.seq, err: fmt.Errorf("hadoop-snappy: decompress block: %w", err)}
+			}
+
+			select {
+			case results <- result:
+			case <-r.done:
+				return
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// collect reorders results, which may arrive out of seq order because
+// workers finish at different times, and republishes them on r.ordered in
+// seq order so Read can consume them as a single, in-order stream. It stops
+// as soon as it has delivered a terminal result (io.EOF or any other
+// error), matching Reader's own Read semantics.
+func (r *ParallelReader) collect(results <-chan parallelResult) {
+	defer close(r.ordered)
+
+	pending := &resultHeap{}
+	heap.Init(pending)
+
+	for {
+		if pending.Len() > 0 && (*pending)[0].seq == r.nextSeq {
+			result := heap.Pop(pending).(parallelResult)
+			r.nextSeq++
+
+			select {
+			case r.ordered <- result:
+			case <-r.done:
+				return
+			}
+
+			if result.err != nil {
+				return
+			}
+
+			continue
+		}
+
+		select {
+		case result, ok := <-results:
+			if !ok {
+				return
+			}
+
+			heap.Push(pending, result)
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// byteSemaphore is a counting semaphore over a number of bytes rather than
+// a number of slots, used to bound the total size of in-flight compressed
+// and decompressed data rather than just the number of in-flight blocks.
+type byteSemaphore struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	cur  int64
+	max  int64
+}
+
+func newByteSemaphore(max int64) *byteSemaphore {
+	s := &byteSemaphore{max: max}
+	s.cond = sync.NewCond(&s.mu)
+
+	return s
+}
+
+// acquire blocks until n bytes are available, unless nothing at all is
+// currently in flight, so a single block larger than max can still make
+// progress instead of deadlocking.
+func (s *byteSemaphore) acquire(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.cur > 0 && s.cur+n > s.max {
+		s.cond.Wait()
+	}
+
+	s.cur += n
+}
+
+func (s *byteSemaphore) release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cur -= n
+	s.cond.Broadcast()
+}
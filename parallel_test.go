@@ -0,0 +1,179 @@
+package snappy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParallelReaderMatchesReader(t *testing.T) {
+	tests := map[string]string{
+		"small json data": "testdata/test.jsonl.snappy",
+		"large text data": "testdata/shakespeare.txt.snappy",
+	}
+
+	for name, path := range tests {
+		t.Run(name, func(t *testing.T) {
+			input := mustReadFile(path)
+
+			want, err := io.ReadAll(NewReader(bytes.NewReader(input)))
+			if err != nil {
+				t.Fatalf("unexpected error from serial Reader: %v", err)
+			}
+
+			for _, concurrency := range []int{1, 2, 4, 8} {
+				pr := NewParallelReader(bytes.NewReader(input), ReaderConcurrency(concurrency))
+				defer pr.Close()
+
+				got, err := io.ReadAll(pr)
+				if err != nil {
+					t.Fatalf("concurrency=%d: unexpected error from ParallelReader: %v", concurrency, err)
+				}
+
+				if !bytes.Equal(want, got) {
+					t.Errorf("concurrency=%d: output does not match serial Reader", concurrency)
+				}
+			}
+		})
+	}
+}
+
+func TestParallelReaderSurfacesErrorsInStreamOrder(t *testing.T) {
+	data := mustReadFile("testdata/test.jsonl.snappy")
+	// Corrupt the block length header the same way TestSnappyReader does,
+	// so the error is only discoverable once this block is reached.
+	data[4] += 1
+
+	pr := NewParallelReader(bytes.NewReader(data), ReaderConcurrency(4))
+	defer pr.Close()
+
+	_, err := io.ReadAll(pr)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got error %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+// TestParallelReaderRepeatsTerminalResult confirms that, like a serial
+// Reader, ParallelReader keeps returning the same terminal io.EOF or error
+// on every Read call after the stream has ended, rather than
+// io.ErrClosedPipe once its internal channel has been drained and closed.
+func TestParallelReaderRepeatsTerminalResult(t *testing.T) {
+	t.Run("EOF", func(t *testing.T) {
+		input := mustReadFile("testdata/test.jsonl.snappy")
+
+		pr := NewParallelReader(bytes.NewReader(input))
+		defer pr.Close()
+
+		if _, err := io.ReadAll(pr); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := pr.Read(make([]byte, 1)); err != io.EOF {
+				t.Fatalf("Read after EOF #%d: got %v, want io.EOF", i, err)
+			}
+		}
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		data := mustReadFile("testdata/test.jsonl.snappy")
+		data[4] += 1
+
+		pr := NewParallelReader(bytes.NewReader(data), ReaderConcurrency(4))
+		defer pr.Close()
+
+		_, firstErr := io.ReadAll(pr)
+		if firstErr == nil {
+			t.Fatal("expected an error from a corrupted stream")
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := pr.Read(make([]byte, 1)); err != firstErr {
+				t.Fatalf("Read after error #%d: got %v, want %v", i, err, firstErr)
+			}
+		}
+	})
+}
+
+// TestParallelReaderSelfCancelsOnError confirms that a terminal decode error
+// from Read leaves no goroutines behind even when the caller never calls
+// Close, the way io.ReadAll (and most other callers) stop reading after a
+// non-EOF error without also calling Close.
+func TestParallelReaderSelfCancelsOnError(t *testing.T) {
+	data := mustReadFile("testdata/test.jsonl.snappy")
+	// Corrupt the block length header the same way
+	// TestParallelReaderSurfacesErrorsInStreamOrder does.
+	data[4] += 1
+
+	before := runtime.NumGoroutine()
+
+	pr := NewParallelReader(bytes.NewReader(data), ReaderConcurrency(4))
+	if _, err := io.ReadAll(pr); err == nil {
+		t.Fatal("expected an error from a corrupted stream")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutines leaked after Read error without Close: before=%d after=%d", before, after)
+	}
+}
+
+func TestParallelReaderRespectsMaxFrameSize(t *testing.T) {
+	input := mustReadFile("testdata/test.jsonl.snappy")
+
+	pr := NewParallelReader(bytes.NewReader(input), ReaderMaxFrameSize(1))
+	defer pr.Close()
+
+	_, err := io.ReadAll(pr)
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("got error %v, want ErrFrameTooLarge", err)
+	}
+}
+
+func TestParallelReaderAcceptsButIgnoresBufferPool(t *testing.T) {
+	input := mustReadFile("testdata/test.jsonl.snappy")
+	want, err := io.ReadAll(NewReader(bytes.NewReader(input)))
+	if err != nil {
+		t.Fatalf("unexpected error from serial Reader: %v", err)
+	}
+
+	pool := &sync.Pool{
+		New: func() interface{} {
+			return new(bytes.Buffer)
+		},
+	}
+
+	pr := NewParallelReader(bytes.NewReader(input), ReaderBufferPool(pool))
+	defer pr.Close()
+
+	got, err := io.ReadAll(pr)
+	if err != nil {
+		t.Fatalf("unexpected error from ParallelReader: %v", err)
+	}
+
+	if !bytes.Equal(want, got) {
+		t.Errorf("output does not match serial Reader")
+	}
+}
+
+func TestParallelReaderEmptyStream(t *testing.T) {
+	pr := NewParallelReader(bytes.NewReader([]byte{}))
+	defer pr.Close()
+
+	output, err := io.ReadAll(pr)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(output) != 0 {
+		t.Errorf("got %q, want empty output", output)
+	}
+}
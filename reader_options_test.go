@@ -0,0 +1,133 @@
+package snappy
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/golang/snappy"
+)
+
+func TestReaderMaxFrameSize(t *testing.T) {
+	input := mustReadFile("testdata/test.jsonl.snappy")
+
+	r := NewReader(bytes.NewReader(input), ReaderMaxFrameSize(1))
+
+	_, err := io.ReadAll(r)
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("got error %v, want ErrFrameTooLarge", err)
+	}
+}
+
+func TestReaderMaxBlockSize(t *testing.T) {
+	input := mustReadFile("testdata/test.jsonl.snappy")
+
+	r := NewReader(bytes.NewReader(input), ReaderMaxBlockSize(1))
+
+	_, err := io.ReadAll(r)
+	if !errors.Is(err, ErrBlockTooLarge) {
+		t.Fatalf("got error %v, want ErrBlockTooLarge", err)
+	}
+}
+
+func TestReaderDefaultLimitsAllowExistingFixtures(t *testing.T) {
+	input := mustReadFile("testdata/shakespeare.txt.snappy")
+	expected := mustReadFile("testdata/shakespeare.txt")
+
+	r := NewReader(bytes.NewReader(input))
+
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error with default limits: %v", err)
+	}
+
+	if !bytes.Equal(expected, output) {
+		t.Errorf("output does not match expected")
+	}
+}
+
+func TestReaderBufferPool(t *testing.T) {
+	pool := &sync.Pool{
+		New: func() interface{} {
+			return new(bytes.Buffer)
+		},
+	}
+
+	input := mustReadFile("testdata/test.jsonl.snappy")
+	expected := mustReadFile("testdata/test.jsonl")
+
+	r := NewReader(bytes.NewReader(input), ReaderBufferPool(pool))
+
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(expected, output) {
+		t.Errorf("output does not match expected")
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("unexpected error closing reader: %v", err)
+	}
+}
+
+// TestReaderBufferPoolReusesDecompressedBacking confirms that decompress
+// actually writes into decompressedBuf's backing array across blocks,
+// rather than Decode seeing a zero-length destination and allocating a
+// fresh output slice every block (which would make ReaderBufferPool's
+// allocation savings illusory on the decompressed side).
+func TestReaderBufferPoolReusesDecompressedBacking(t *testing.T) {
+	pool := &sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	r := newReaderWithConfig(nil, newReaderConfig([]ReaderOption{ReaderBufferPool(pool)}))
+
+	backingPtr := func() uintptr {
+		return reflect.ValueOf(r.decompressedBuf.Bytes()[:0:cap(r.decompressedBuf.Bytes())]).Pointer()
+	}
+
+	decompressOneBlock := func(plain []byte) {
+		r.compressedBuf.Reset()
+		r.compressedBuf.Write(snappy.Encode(nil, plain))
+		r.frameRemaining = len(plain)
+
+		if err := r.decompress(); err != nil {
+			t.Fatalf("decompress: %v", err)
+		}
+	}
+
+	decompressOneBlock(bytes.Repeat([]byte("a"), 1000))
+	first := backingPtr()
+
+	decompressOneBlock(bytes.Repeat([]byte("b"), 1000))
+	second := backingPtr()
+
+	if first != second {
+		t.Errorf("decompressedBuf backing array was not reused across blocks")
+	}
+}
+
+// TestReaderBufferPoolWithoutNew confirms that a *sync.Pool whose New is
+// unset (the valid, idiomatic zero value) works the same as a pool with no
+// New, rather than panicking on the first Get.
+func TestReaderBufferPoolWithoutNew(t *testing.T) {
+	input := mustReadFile("testdata/test.jsonl.snappy")
+	expected := mustReadFile("testdata/test.jsonl")
+
+	r := NewReader(bytes.NewReader(input), ReaderBufferPool(&sync.Pool{}))
+
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(expected, output) {
+		t.Errorf("output does not match expected")
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("unexpected error closing reader: %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package snappy
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SeekableReader provides random access to a hadoop-snappy stream backed by
+// an io.ReaderAt, using an Index built by BuildIndex to jump directly to
+// the frame containing any given offset instead of decompressing the whole
+// stream.
+type SeekableReader struct {
+	r    io.ReaderAt
+	idx  *Index
+	cfg  readerConfig
+	size int64
+
+	pos int64
+	dec *Reader
+	// decPos is the uncompressed offset dec will next yield. dec itself
+	// continues decoding across frame boundaries on its own, so it only
+	// needs to be reinitialized when pos diverges from decPos, i.e. after
+	// a Seek actually moved the position.
+	decPos int64
+}
+
+// NewSeekableReader returns a SeekableReader over the hadoop-snappy stream
+// read from r, using idx to locate frames. idx must have been built from
+// the same stream, typically with BuildIndex.
+func NewSeekableReader(r io.ReaderAt, idx *Index, opts ...ReaderOption) *SeekableReader {
+	var size int64
+	if n := len(idx.entries); n > 0 {
+		last := idx.entries[n-1]
+		size = last.uncompressedOffset + last.uncompressedLen
+	}
+
+	return &SeekableReader{
+		r:    r,
+		idx:  idx,
+		cfg:  newReaderConfig(opts),
+		size: size,
+	}
+}
+
+// Seek implements the io.Seeker interface. It does not itself touch the
+// underlying stream; the seek only takes effect on the next Read. ReadAt
+// ignores the position set by Seek.
+func (s *SeekableReader) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = s.pos + offset
+	case io.SeekEnd:
+		target = s.size + offset
+	default:
+		return 0, fmt.Errorf("hadoop-snappy: seek: invalid whence %d", whence)
+	}
+
+	if target < 0 {
+		return 0, fmt.Errorf("hadoop-snappy: seek: negative position")
+	}
+
+	s.pos = target
+
+	return target, nil
+}
+
+// Read implements the io.Reader interface, returning decompressed bytes
+// starting from the current seek position.
+func (s *SeekableReader) Read(out []byte) (int, error) {
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+
+	if s.dec == nil || s.pos != s.decPos {
+		if err := s.openFrame(s.pos); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := s.dec.Read(out)
+	s.pos += int64(n)
+	s.decPos += int64(n)
+
+	return n, err
+}
+
+// ReadAt implements the io.ReaderAt interface. Unlike Read, it does not
+// affect the position used by Read and Seek.
+func (s *SeekableReader) ReadAt(p []byte, off int64) (int, error) {
+	tmp := &SeekableReader{r: s.r, idx: s.idx, cfg: s.cfg, size: s.size}
+
+	if _, err := tmp.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := io.ReadFull(tmp, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// openFrame binary-searches the index for the frame containing the
+// uncompressed offset pos, reinitializes a Reader at that frame's
+// compressed offset, and discards the bytes of the frame before pos.
+func (s *SeekableReader) openFrame(pos int64) error {
+	entries := s.idx.entries
+
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].uncompressedOffset+entries[i].uncompressedLen > pos
+	})
+	if i == len(entries) {
+		return io.EOF
+	}
+
+	entry := entries[i]
+
+	section := io.NewSectionReader(s.r, entry.compressedOffset, s.sizeFrom(entry.compressedOffset))
+	s.dec = newReaderWithConfig(section, s.cfg)
+	s.decPos = entry.uncompressedOffset
+
+	if skip := pos - entry.uncompressedOffset; skip > 0 {
+		if _, err := io.CopyN(io.Discard, s.dec, skip); err != nil {
+			return fmt.Errorf("hadoop-snappy: seek: discard %d bytes into frame: %w", skip, err)
+		}
+
+		s.decPos += skip
+	}
+
+	return nil
+}
+
+// sizeFrom returns a section length large enough to read to the end of the
+// underlying stream starting at off; the exact compressed length of the
+// stream isn't known to the Index, so this relies on the underlying
+// io.ReaderAt returning io.EOF once its real extent is reached.
+func (s *SeekableReader) sizeFrom(off int64) int64 {
+	const maxSectionLen = int64(1) << 62
+	return maxSectionLen - off
+}
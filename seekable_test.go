@@ -0,0 +1,85 @@
+package snappy
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func buildSeekableFixture(t *testing.T, input []byte, frameSize, blockSize int) ([]byte, *Index) {
+	t.Helper()
+
+	var compressed bytes.Buffer
+	w := NewWriterSize(&compressed, frameSize, blockSize)
+	if _, err := w.Write(input); err != nil {
+		t.Fatalf("unexpected error writing input: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	data := compressed.Bytes()
+
+	idx, err := BuildIndex(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error building index: %v", err)
+	}
+
+	return data, idx
+}
+
+func TestSeekableReaderReadsSequentially(t *testing.T) {
+	input := mustReadFile("testdata/shakespeare.txt")
+	data, idx := buildSeekableFixture(t, input, 4096, 1024)
+
+	sr := NewSeekableReader(bytes.NewReader(data), idx)
+
+	output, err := io.ReadAll(sr)
+	if err != nil {
+		t.Fatalf("unexpected error reading: %v", err)
+	}
+
+	if !bytes.Equal(input, output) {
+		t.Errorf("sequential read through SeekableReader does not match input")
+	}
+}
+
+func TestSeekableReaderSeek(t *testing.T) {
+	input := mustReadFile("testdata/shakespeare.txt")
+	data, idx := buildSeekableFixture(t, input, 4096, 1024)
+
+	sr := NewSeekableReader(bytes.NewReader(data), idx)
+
+	offsets := []int64{0, 1, 4095, 4096, 4097, int64(len(input) / 2), int64(len(input) - 10)}
+	for _, off := range offsets {
+		if _, err := sr.Seek(off, io.SeekStart); err != nil {
+			t.Fatalf("seek to %d: unexpected error: %v", off, err)
+		}
+
+		got, err := io.ReadAll(sr)
+		if err != nil {
+			t.Fatalf("seek to %d: unexpected error reading: %v", off, err)
+		}
+
+		if want := input[off:]; !bytes.Equal(want, got) {
+			t.Errorf("seek to %d: got %d bytes, want %d bytes matching input tail", off, len(got), len(want))
+		}
+	}
+}
+
+func TestSeekableReaderReadAt(t *testing.T) {
+	input := mustReadFile("testdata/shakespeare.txt")
+	data, idx := buildSeekableFixture(t, input, 4096, 1024)
+
+	sr := NewSeekableReader(bytes.NewReader(data), idx)
+
+	buf := make([]byte, 100)
+	n, err := sr.ReadAt(buf, 4090)
+	if err != nil && err != io.EOF {
+		t.Fatalf("unexpected error from ReadAt: %v", err)
+	}
+
+	if want := input[4090 : 4090+int64(n)]; !bytes.Equal(want, buf[:n]) {
+		t.Errorf("ReadAt returned data that does not match input at that offset")
+	}
+}
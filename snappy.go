@@ -1,6 +1,8 @@
 /*
-Package snappy implements decompression for the Hadoop format of snappy; a
-compression scheme internal to the Hadoop ecosystem and HDFS.
+Package snappy implements compression and decompression for the Hadoop
+framing of snappy used within the Hadoop ecosystem and HDFS, and can also
+auto-detect and decompress the xerial/snappy-java framing used by tools
+such as Kafka; see NewAutoReader.
 */
 package snappy
 
@@ -10,6 +12,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/golang/snappy"
 )
@@ -17,13 +20,109 @@ import (
 const (
 	// headerLength is the length in bytes of both the frame and block headers.
 	headerLength = 4
+
+	// defaultMaxFrameSize is the default limit on a frame's advertised
+	// uncompressed size, used when ReaderMaxFrameSize is not supplied.
+	defaultMaxFrameSize = 512 * 1024 * 1024
+
+	// defaultMaxBlockSize is the default limit on a block's advertised
+	// compressed size, used when ReaderMaxBlockSize is not supplied.
+	defaultMaxBlockSize = 64 * 1024 * 1024
 )
 
 var (
 	errEmptyBlock           = errors.New("hadoop-snappy: zero length block in input stream")
 	errDecompressedTooLarge = errors.New("decompressed frame larger than expected")
+
+	// ErrFrameTooLarge is returned when a frame header advertises an
+	// uncompressed size larger than the configured ReaderMaxFrameSize.
+	ErrFrameTooLarge = errors.New("hadoop-snappy: frame size exceeds configured maximum")
+	// ErrBlockTooLarge is returned when a block header advertises a
+	// compressed size larger than the configured ReaderMaxBlockSize.
+	ErrBlockTooLarge = errors.New("hadoop-snappy: block size exceeds configured maximum")
 )
 
+// readerConfig holds the settings ReaderOptions apply. It is shared by
+// Reader and ParallelReader so both can be configured with the same
+// options.
+type readerConfig struct {
+	// maxFrameSize and maxBlockSize bound the sizes a frame or block
+	// header may advertise, so a malicious header can't force large
+	// allocations before any data has been validated.
+	maxFrameSize int
+	maxBlockSize int
+	// bufferPool, when set, supplies and reclaims the *bytes.Buffer
+	// values backing a Reader's decompressedBuf and compressedBuf.
+	bufferPool *sync.Pool
+	// concurrency is the number of worker goroutines a ParallelReader
+	// decodes blocks with; it has no effect on a serial Reader.
+	concurrency int
+}
+
+func newReaderConfig(opts []ReaderOption) readerConfig {
+	cfg := readerConfig{
+		maxFrameSize: defaultMaxFrameSize,
+		maxBlockSize: defaultMaxBlockSize,
+		concurrency:  defaultConcurrency,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
+
+// ReaderOption configures optional behavior of a Reader constructed by
+// NewReader, or a ParallelReader constructed by NewParallelReader.
+type ReaderOption func(*readerConfig)
+
+// ReaderMaxFrameSize bounds the uncompressed size a frame header is allowed
+// to advertise. Reading a frame header larger than n returns
+// ErrFrameTooLarge before any data is read or allocated.
+func ReaderMaxFrameSize(n int) ReaderOption {
+	return func(c *readerConfig) {
+		c.maxFrameSize = n
+	}
+}
+
+// ReaderMaxBlockSize bounds the compressed size a block header is allowed
+// to advertise. Reading a block header larger than n returns
+// ErrBlockTooLarge before any data is read or allocated.
+func ReaderMaxBlockSize(n int) ReaderOption {
+	return func(c *readerConfig) {
+		c.maxBlockSize = n
+	}
+}
+
+// ReaderBufferPool supplies a *sync.Pool of *bytes.Buffer that the Reader
+// draws its internal buffers from instead of allocating new ones, and
+// returns them to when Close is called. This is useful for allocation-
+// sensitive pipelines that construct many short-lived Readers. p's New
+// field is not required to be set; Get is allowed to return nil or any
+// value that is not a *bytes.Buffer, in which case a fresh *bytes.Buffer
+// is allocated instead, exactly as it would be on a pool with no New.
+//
+// ParallelReader accepts this option but does not use it; see
+// NewParallelReader.
+func ReaderBufferPool(p *sync.Pool) ReaderOption {
+	return func(c *readerConfig) {
+		c.bufferPool = p
+	}
+}
+
+// bufferFromPool draws a *bytes.Buffer from p, tolerating a pool whose New
+// is unset (or otherwise produces something other than a *bytes.Buffer) by
+// falling back to a fresh one, the same way such a pool behaves once
+// something has actually been Put into it.
+func bufferFromPool(p *sync.Pool) *bytes.Buffer {
+	if buf, ok := p.Get().(*bytes.Buffer); ok && buf != nil {
+		return buf
+	}
+
+	return new(bytes.Buffer)
+}
+
 // Reader wraps a hadoop-snappy compressed data stream and decompresses
 // the stream as it is read by the caller.
 type Reader struct {
@@ -35,13 +134,16 @@ type Reader struct {
 	// decompressedBuf is used simply as a mechanism to resize
 	// and reuse the underlying buffer as we are decompressing
 	// blocks, to avoid reallocating a slice unnecessarily.
-	decompressedBuf bytes.Buffer
+	decompressedBuf *bytes.Buffer
 	// compressedBuf is used to read the compressed data from
 	// the input stream.
-	compressedBuf bytes.Buffer
+	compressedBuf *bytes.Buffer
 	// frameRemaining represents how many more uncompressed bytes
 	// we have left in the current frame.
 	frameRemaining int
+	// cfg holds the limits and buffer pool this Reader was configured
+	// with.
+	cfg readerConfig
 }
 
 // NewReader returns a Reader that can read the hadoop-snappy compressed format
@@ -51,11 +153,49 @@ type Reader struct {
 // in undefined behavior. Because there is no data signature to detect the
 // compression format, the reader can only try to read the stream and will likely
 // return an error, but it may return garbage data instead.
-func NewReader(in io.Reader) *Reader {
-	return &Reader{
+//
+// By default, frame and block headers are bounded by defaultMaxFrameSize and
+// defaultMaxBlockSize; use ReaderMaxFrameSize and ReaderMaxBlockSize to
+// configure tighter limits when reading untrusted input.
+func NewReader(in io.Reader, opts ...ReaderOption) *Reader {
+	return newReaderWithConfig(in, newReaderConfig(opts))
+}
+
+// newReaderWithConfig builds a Reader from an already-resolved readerConfig,
+// so callers that need to construct a Reader internally (SeekableReader, in
+// particular) can reuse the options a caller already applied without
+// re-parsing them.
+func newReaderWithConfig(in io.Reader, cfg readerConfig) *Reader {
+	r := &Reader{
 		in:           in,
 		currentBlock: bytes.NewReader([]byte{}),
+		cfg:          cfg,
+	}
+
+	if cfg.bufferPool != nil {
+		r.decompressedBuf = bufferFromPool(cfg.bufferPool)
+		r.compressedBuf = bufferFromPool(cfg.bufferPool)
+	} else {
+		r.decompressedBuf = new(bytes.Buffer)
+		r.compressedBuf = new(bytes.Buffer)
+	}
+
+	return r
+}
+
+// Close releases the Reader's internal buffers back to its ReaderBufferPool,
+// if one was configured. It is a no-op otherwise.
+func (r *Reader) Close() error {
+	if r.cfg.bufferPool == nil {
+		return nil
 	}
+
+	r.decompressedBuf.Reset()
+	r.compressedBuf.Reset()
+	r.cfg.bufferPool.Put(r.decompressedBuf)
+	r.cfg.bufferPool.Put(r.compressedBuf)
+
+	return nil
 }
 
 // Read implements the io.Reader interface. Read will return the
@@ -94,6 +234,10 @@ func (r *Reader) nextFrame() error {
 		return fmt.Errorf("hadoop-snappy: read frame header: %w", err)
 	}
 
+	if uncompressedSize > r.cfg.maxFrameSize {
+		return fmt.Errorf("hadoop-snappy: frame header: %w", ErrFrameTooLarge)
+	}
+
 	r.frameRemaining = uncompressedSize
 
 	return nil
@@ -122,8 +266,12 @@ func (r *Reader) readNextBlock() error {
 		return errEmptyBlock
 	}
 
+	if nextBlockLength > r.cfg.maxBlockSize {
+		return fmt.Errorf("hadoop-snappy: block header: %w", ErrBlockTooLarge)
+	}
+
 	r.compressedBuf.Reset()
-	_, err = io.CopyN(&r.compressedBuf, r.in, int64(nextBlockLength))
+	_, err = io.CopyN(r.compressedBuf, r.in, int64(nextBlockLength))
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			// We should not encounter EOF here because we should have at least
@@ -150,10 +298,17 @@ func (r *Reader) decompress() error {
 
 	r.frameRemaining -= decompressedLength
 
+	// Reset+Grow only guarantees capacity; Bytes() would still report a
+	// zero-length slice, so Decode would never actually see a destination
+	// large enough to reuse and would allocate fresh output every block.
+	// AvailableBuffer reslices that same spare capacity to the length
+	// Decode needs, so a pooled buffer's backing array is genuinely reused
+	// across blocks.
 	r.decompressedBuf.Reset()
 	r.decompressedBuf.Grow(decompressedLength)
+	dst := r.decompressedBuf.AvailableBuffer()[:decompressedLength]
 
-	decompressed, err := snappy.Decode(r.decompressedBuf.Bytes(), r.compressedBuf.Bytes())
+	decompressed, err := snappy.Decode(dst, r.compressedBuf.Bytes())
 	if err != nil {
 		return fmt.Errorf("hadoop-snappy: decompress block: %w", err)
 	}
@@ -143,6 +143,106 @@ func TestSnappyReader(t *testing.T) {
 	}
 }
 
+// FuzzReader exercises Reader with malformed and arbitrary input, seeded
+// with the existing testdata fixtures plus the hand-corrupted variants used
+// by TestSnappyReader. It checks that Read never panics and that any error
+// it returns is one this package actually documents, rather than some
+// unexpected failure mode.
+func FuzzReader(f *testing.F) {
+	for _, name := range []string{
+		"testdata/test.jsonl.snappy",
+		"testdata/shakespeare.txt.snappy",
+		"testdata/truncated.snappy",
+	} {
+		f.Add(mustReadFile(name))
+	}
+
+	base := mustReadFile("testdata/test.jsonl.snappy")
+	for _, mutate := range []func([]byte) []byte{
+		func(d []byte) []byte { d = append([]byte{}, d...); d[0]++; return d },
+		func(d []byte) []byte { d = append([]byte{}, d...); d[3]--; return d },
+		func(d []byte) []byte { d = append([]byte{}, d...); d[4]++; return d },
+		func(d []byte) []byte { d = append([]byte{}, d...); d[7]++; return d },
+		func(d []byte) []byte {
+			d = append([]byte{}, d...)
+			d[8], d[9], d[10], d[11] = 0xFF, 0xFF, 0xFF, 0xFF
+			return d
+		},
+		func(d []byte) []byte {
+			blockSize := int(binary.BigEndian.Uint32(d[4:8])) - 2
+			return append(append([]byte{}, d[:10]...), bytes.Repeat([]byte{0xFF}, blockSize)...)
+		},
+	} {
+		f.Add(mutate(base))
+	}
+	f.Add([]byte{0x00, 0x00, 0x00, 0xFF, 0x00, 0x00, 0x00, 0x00})
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkFuzzInput(t, NewReader(bytes.NewReader(data)), data)
+
+		// Re-run with tight limits so the ReaderMaxFrameSize/ReaderMaxBlockSize
+		// bounding paths get fuzzed too, not just the unbounded defaults.
+		checkFuzzInput(t, NewReader(bytes.NewReader(data), ReaderMaxFrameSize(64), ReaderMaxBlockSize(64)), data)
+	})
+}
+
+// checkFuzzInput reads r to completion and fails t if it panics, returns an
+// error this package doesn't document, or returns more decompressed bytes
+// than the frame headers found in data advertise.
+func checkFuzzInput(t *testing.T, r *Reader, data []byte) {
+	t.Helper()
+
+	n, err := io.Copy(io.Discard, r)
+	checkFuzzDecodedLen(t, data, n)
+
+	if err == nil {
+		return
+	}
+
+	for _, sentinel := range []error{
+		errEmptyBlock,
+		errDecompressedTooLarge,
+		io.ErrUnexpectedEOF,
+		io.EOF,
+		gsnappy.ErrCorrupt,
+		ErrFrameTooLarge,
+		ErrBlockTooLarge,
+	} {
+		if errors.Is(err, sentinel) {
+			return
+		}
+	}
+
+	t.Fatalf("unexpected error from reader: %v", err)
+}
+
+// checkFuzzDecodedLen fails t if got, the total number of decompressed bytes
+// a reader returned for data, exceeds the sum of the uncompressed frame
+// sizes BuildIndex finds by independently walking data's frame headers. A
+// reader that returns more decoded bytes than its own frame headers
+// advertised has a decoding bug, not just a malformed-input error.
+// BuildIndex errors are ignored: they mean data is too malformed to walk
+// independently, in which case checkFuzzInput's sentinel check already
+// covers whatever error the reader itself produced.
+func checkFuzzDecodedLen(t *testing.T, data []byte, got int64) {
+	t.Helper()
+
+	idx, err := BuildIndex(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	var want int64
+	for _, e := range idx.entries {
+		want += e.uncompressedLen
+	}
+
+	if got > want {
+		t.Fatalf("reader returned %d decompressed bytes, exceeding %d advertised by data's frame headers", got, want)
+	}
+}
+
 func mustReadFile(filename string) []byte {
 	file, err := os.Open(filename)
 	if err != nil {
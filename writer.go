@@ -0,0 +1,240 @@
+package snappy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+)
+
+// Default sizing used by NewWriter. DefaultFrameSize is the target number of
+// uncompressed bytes buffered before a frame is emitted, and DefaultBlockSize
+// is the maximum number of uncompressed bytes placed in any single block
+// within a frame.
+const (
+	DefaultFrameSize = 256 * 1024
+	DefaultBlockSize = 64 * 1024
+)
+
+var errWriterClosed = errors.New("hadoop-snappy: write to closed writer")
+
+// Writer compresses data written to it into the hadoop-snappy format and
+// writes it to an underlying io.Writer. A Writer produced by this package
+// writes a stream that the Reader in this package can decompress.
+type Writer struct {
+	// w is the underlying writer the compressed stream is written to.
+	w io.Writer
+	// frameSize is the target number of uncompressed bytes buffered in buf
+	// before a frame is flushed.
+	frameSize int
+	// blockSize is the maximum number of uncompressed bytes encoded into
+	// any single block of a frame.
+	blockSize int
+	// buf accumulates uncompressed bytes for the frame currently being
+	// built.
+	buf bytes.Buffer
+	// encodeBuf is reused across calls to snappy.Encode to avoid
+	// reallocating a buffer for every block.
+	encodeBuf []byte
+	// closed is set once Close has been called, after which further
+	// writes are rejected.
+	closed bool
+	// err is the first error encountered writing to w; once set, it is
+	// returned by all subsequent operations.
+	err error
+}
+
+// NewWriter returns a Writer that writes the hadoop-snappy compressed format
+// to w, using DefaultFrameSize and DefaultBlockSize.
+func NewWriter(w io.Writer) *Writer {
+	return NewWriterSize(w, DefaultFrameSize, DefaultBlockSize)
+}
+
+// NewWriterSize is like NewWriter but allows the target uncompressed frame
+// size and per-block size to be configured. frameSize controls how many
+// uncompressed bytes are buffered before a frame is written out; blockSize
+// controls how those buffered bytes are chunked into blocks within a frame.
+//
+// frameSize and blockSize must be positive; a non-positive value would
+// never flush, spinning Write, Flush, or Close forever, so it is replaced
+// with DefaultFrameSize or DefaultBlockSize instead.
+func NewWriterSize(w io.Writer, frameSize, blockSize int) *Writer {
+	if frameSize <= 0 {
+		frameSize = DefaultFrameSize
+	}
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+
+	return &Writer{
+		w:         w,
+		frameSize: frameSize,
+		blockSize: blockSize,
+	}
+}
+
+// Write implements the io.Writer interface. The data passed to Write is
+// buffered and will not necessarily be flushed to the underlying writer
+// until enough data has accumulated to fill a frame, or until Flush or
+// Close is called.
+func (w *Writer) Write(p []byte) (int, error) {
+	if err := w.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	n, err := w.buf.Write(p)
+	if err != nil {
+		w.err = err
+		return n, err
+	}
+
+	for w.buf.Len() >= w.frameSize {
+		if err := w.flushFrame(w.frameSize); err != nil {
+			w.err = err
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// ReadFrom implements the io.ReaderFrom interface, reading from r until
+// io.EOF and writing the result in the hadoop-snappy format. It is
+// equivalent to repeatedly calling Write, but avoids an extra copy by the
+// caller.
+func (w *Writer) ReadFrom(r io.Reader) (int64, error) {
+	if err := w.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	chunk := make([]byte, 32*1024)
+
+	var total int64
+	for {
+		rn, rerr := r.Read(chunk)
+		if rn > 0 {
+			_, werr := w.Write(chunk[:rn])
+			total += int64(rn)
+			if werr != nil {
+				return total, werr
+			}
+		}
+
+		if rerr != nil {
+			if rerr == io.EOF {
+				return total, nil
+			}
+
+			return total, rerr
+		}
+	}
+}
+
+// Flush forces any buffered data to be written out as a frame, even if it
+// is smaller than the configured frame size. Flush does not close w.
+func (w *Writer) Flush() error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+
+	for w.buf.Len() >= w.frameSize {
+		if err := w.flushFrame(w.frameSize); err != nil {
+			w.err = err
+			return err
+		}
+	}
+
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	if err := w.flushFrame(w.buf.Len()); err != nil {
+		w.err = err
+		return err
+	}
+
+	return nil
+}
+
+// Close flushes any buffered data as a final frame. Close does not close
+// the underlying io.Writer. After Close is called, further writes return
+// an error.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+
+	err := w.Flush()
+	w.closed = true
+
+	return err
+}
+
+func (w *Writer) checkWritable() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.closed {
+		return errWriterClosed
+	}
+
+	return nil
+}
+
+// flushFrame writes the first n bytes currently in buf as a single frame,
+// split into one or more blocks of at most blockSize uncompressed bytes
+// each.
+func (w *Writer) flushFrame(n int) error {
+	data := w.buf.Next(n)
+
+	if err := writeHeader(w.w, len(data)); err != nil {
+		return fmt.Errorf("hadoop-snappy: write frame header: %w", err)
+	}
+
+	for len(data) > 0 {
+		chunk := data
+		if len(chunk) > w.blockSize {
+			chunk = chunk[:w.blockSize]
+		}
+
+		if err := w.writeBlock(chunk); err != nil {
+			return err
+		}
+
+		data = data[len(chunk):]
+	}
+
+	return nil
+}
+
+func (w *Writer) writeBlock(chunk []byte) error {
+	maxLen := snappy.MaxEncodedLen(len(chunk))
+	if cap(w.encodeBuf) < maxLen {
+		w.encodeBuf = make([]byte, maxLen)
+	}
+
+	encoded := snappy.Encode(w.encodeBuf[:maxLen], chunk)
+
+	if err := writeHeader(w.w, len(encoded)); err != nil {
+		return fmt.Errorf("hadoop-snappy: write block header: %w", err)
+	}
+
+	if _, err := w.w.Write(encoded); err != nil {
+		return fmt.Errorf("hadoop-snappy: write block: %w", err)
+	}
+
+	return nil
+}
+
+func writeHeader(w io.Writer, n int) error {
+	headerBuf := make([]byte, headerLength)
+	binary.BigEndian.PutUint32(headerBuf, uint32(n))
+
+	_, err := w.Write(headerBuf)
+
+	return err
+}
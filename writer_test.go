@@ -0,0 +1,178 @@
+package snappy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func TestSnappyWriter(t *testing.T) {
+	tests := map[string]struct {
+		input []byte
+	}{
+		"round-trips small json data": {
+			input: mustReadFile("testdata/test.jsonl"),
+		},
+		"round-trips large text data": {
+			input: mustReadFile("testdata/shakespeare.txt"),
+		},
+		"round-trips empty input": {
+			input: []byte{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var compressed bytes.Buffer
+
+			w := NewWriter(&compressed)
+			if _, err := w.Write(test.input); err != nil {
+				t.Fatalf("unexpected error writing input: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("unexpected error closing writer: %v", err)
+			}
+
+			output, err := io.ReadAll(NewReader(&compressed))
+			if err != nil {
+				t.Fatalf("unexpected error decompressing round-tripped data: %v", err)
+			}
+
+			if !bytes.Equal(test.input, output) {
+				t.Errorf("round-tripped output does not match input")
+			}
+		})
+	}
+}
+
+func TestSnappyWriterMultipleFramesAndBlocks(t *testing.T) {
+	input := mustReadFile("testdata/shakespeare.txt")
+
+	var compressed bytes.Buffer
+
+	// Use a small frame and block size so the fixture spans many frames
+	// and many blocks per frame.
+	w := NewWriterSize(&compressed, 4096, 1024)
+	if _, err := w.Write(input); err != nil {
+		t.Fatalf("unexpected error writing input: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	// A single Write call spanning many times the frame size must still be
+	// split into frameSize-sized frames, not written out as one oversized
+	// frame: the first frame header should advertise 4096 bytes, not the
+	// full length of input.
+	if firstFrameSize := binary.BigEndian.Uint32(compressed.Bytes()[:4]); firstFrameSize != 4096 {
+		t.Errorf("first frame advertises %d uncompressed bytes, want 4096", firstFrameSize)
+	}
+
+	output, err := io.ReadAll(NewReader(&compressed))
+	if err != nil {
+		t.Fatalf("unexpected error decompressing round-tripped data: %v", err)
+	}
+
+	if !bytes.Equal(input, output) {
+		t.Errorf("round-tripped output does not match input")
+	}
+}
+
+func TestNewWriterSizeRejectsNonPositiveSizes(t *testing.T) {
+	tests := map[string]struct {
+		frameSize, blockSize int
+	}{
+		"zero frame size":     {frameSize: 0, blockSize: 1024},
+		"zero block size":     {frameSize: 4096, blockSize: 0},
+		"negative frame size": {frameSize: -1, blockSize: 1024},
+		"negative block size": {frameSize: 4096, blockSize: -1},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var compressed bytes.Buffer
+
+			w := NewWriterSize(&compressed, test.frameSize, test.blockSize)
+			if w.frameSize <= 0 || w.blockSize <= 0 {
+				t.Fatalf("NewWriterSize kept a non-positive size: frameSize=%d, blockSize=%d", w.frameSize, w.blockSize)
+			}
+
+			// Writing and closing must complete rather than spin forever on
+			// a size that can never be reached.
+			if _, err := w.Write([]byte("hello, world!")); err != nil {
+				t.Fatalf("unexpected error writing input: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("unexpected error closing writer: %v", err)
+			}
+		})
+	}
+}
+
+func TestSnappyWriterFlush(t *testing.T) {
+	input := []byte("hello, world!")
+
+	var compressed bytes.Buffer
+
+	w := NewWriter(&compressed)
+	if _, err := w.Write(input); err != nil {
+		t.Fatalf("unexpected error writing input: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error flushing writer: %v", err)
+	}
+
+	if compressed.Len() == 0 {
+		t.Fatalf("expected Flush to write buffered data before Close")
+	}
+
+	output, err := io.ReadAll(NewReader(bytes.NewReader(compressed.Bytes())))
+	if err != nil {
+		t.Fatalf("unexpected error decompressing flushed data: %v", err)
+	}
+
+	if !bytes.Equal(input, output) {
+		t.Errorf("flushed output does not match input")
+	}
+}
+
+func TestSnappyWriterAfterClose(t *testing.T) {
+	var compressed bytes.Buffer
+
+	w := NewWriter(&compressed)
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	if _, err := w.Write([]byte("more data")); err == nil {
+		t.Fatalf("expected an error writing to a closed writer")
+	}
+}
+
+func TestSnappyWriterReadFrom(t *testing.T) {
+	input := mustReadFile("testdata/shakespeare.txt")
+
+	var compressed bytes.Buffer
+
+	w := NewWriter(&compressed)
+	n, err := w.ReadFrom(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error from ReadFrom: %v", err)
+	}
+	if n != int64(len(input)) {
+		t.Errorf("ReadFrom returned %d, want %d", n, len(input))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error closing writer: %v", err)
+	}
+
+	output, err := io.ReadAll(NewReader(&compressed))
+	if err != nil {
+		t.Fatalf("unexpected error decompressing round-tripped data: %v", err)
+	}
+
+	if !bytes.Equal(input, output) {
+		t.Errorf("round-tripped output does not match input")
+	}
+}
@@ -0,0 +1,160 @@
+package snappy
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+)
+
+// xerialMagic is the 8-byte magic header written at the start of every
+// xerial/snappy-java framed stream, as produced by Kafka and other JVM
+// tooling.
+var xerialMagic = []byte{0x82, 'S', 'N', 'A', 'P', 'P', 'Y', 0x00}
+
+// xerialReaderVersion is the xerial framing version this package knows how
+// to decode.
+const xerialReaderVersion = 1
+
+// ErrUnknownFormat is returned by NewAutoReader when the start of the input
+// stream cannot be read to detect its format, or when a xerial/snappy-java
+// framed stream declares a compatibility version newer than this package
+// understands. A stream without the xerial magic header is always treated
+// as hadoop-snappy, so no other input is rejected with this error.
+var ErrUnknownFormat = errors.New("hadoop-snappy: unknown or unsupported snappy stream format")
+
+// NewAutoReader peeks at the start of r and returns a Reader appropriate
+// for the framing format it detects: a xerial/snappy-java framed stream
+// (as emitted by Kafka producers and other JVM tooling) if the xerial
+// magic header is present, or the hadoop-snappy Reader from NewReader
+// otherwise. opts configures either Reader the same way it would
+// NewReader, including ReaderMaxFrameSize and ReaderMaxBlockSize, except
+// that ReaderBufferPool has no effect on a xerial stream.
+func NewAutoReader(r io.Reader, opts ...ReaderOption) (io.Reader, error) {
+	cfg := newReaderConfig(opts)
+	br := bufio.NewReader(r)
+
+	peeked, err := br.Peek(len(xerialMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("hadoop-snappy: detect stream format: %w: %v", ErrUnknownFormat, err)
+	}
+
+	if bytes.Equal(peeked, xerialMagic) {
+		return newXerialReader(br, cfg)
+	}
+
+	return newReaderWithConfig(br, cfg), nil
+}
+
+// xerialReader decompresses a xerial/snappy-java framed stream.
+type xerialReader struct {
+	// in is the compressed data stream we are reading from.
+	in io.Reader
+	// version is the xerial format version declared by the stream.
+	version int
+	// currentBlock is a chunk we have decompressed and are currently
+	// reading from.
+	currentBlock *bytes.Reader
+	// decompressedBuf and compressedBuf are reused across chunks to
+	// avoid reallocating a buffer for every chunk, mirroring Reader.
+	decompressedBuf bytes.Buffer
+	compressedBuf   bytes.Buffer
+	// cfg holds the limits this xerialReader was configured with; chunks
+	// are bounded the same way hadoop-snappy blocks and frames are by
+	// Reader, since xerial input is just as untrusted.
+	cfg readerConfig
+}
+
+func newXerialReader(in io.Reader, cfg readerConfig) (*xerialReader, error) {
+	header := make([]byte, len(xerialMagic))
+	if _, err := io.ReadFull(in, header); err != nil {
+		return nil, fmt.Errorf("hadoop-snappy: read xerial magic: %w", err)
+	}
+
+	version, err := readHeader(in)
+	if err != nil {
+		return nil, fmt.Errorf("hadoop-snappy: read xerial version: %w", err)
+	}
+
+	compatibleVersion, err := readHeader(in)
+	if err != nil {
+		return nil, fmt.Errorf("hadoop-snappy: read xerial compatible version: %w", err)
+	}
+
+	if compatibleVersion > xerialReaderVersion {
+		return nil, fmt.Errorf("hadoop-snappy: xerial stream requires reader version %d, this package supports %d: %w", compatibleVersion, xerialReaderVersion, ErrUnknownFormat)
+	}
+
+	return &xerialReader{
+		in:           in,
+		version:      version,
+		currentBlock: bytes.NewReader([]byte{}),
+		cfg:          cfg,
+	}, nil
+}
+
+// Read implements the io.Reader interface, returning decompressed data
+// read from the xerial compressed input stream.
+func (r *xerialReader) Read(out []byte) (int, error) {
+	if r.currentBlock.Len() > 0 {
+		return r.currentBlock.Read(out)
+	}
+
+	if err := r.nextChunk(); err != nil {
+		return 0, err
+	}
+
+	return r.currentBlock.Read(out)
+}
+
+func (r *xerialReader) nextChunk() error {
+	chunkLength, err := readHeader(r.in)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			// The start of a new chunk is the one spot we expect to reach
+			// EOF. To work correctly, Read must return EOF itself, not an
+			// error wrapping EOF, because callers will test for EOF using ==.
+			return io.EOF
+		}
+
+		return fmt.Errorf("hadoop-snappy: read xerial chunk header: %w", err)
+	}
+
+	if chunkLength > r.cfg.maxBlockSize {
+		return fmt.Errorf("hadoop-snappy: xerial chunk header: %w", ErrBlockTooLarge)
+	}
+
+	r.compressedBuf.Reset()
+	if _, err := io.CopyN(&r.compressedBuf, r.in, int64(chunkLength)); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return fmt.Errorf("hadoop-snappy: read xerial chunk: %w", err)
+	}
+
+	decompressedLength, err := snappy.DecodedLen(r.compressedBuf.Bytes())
+	if err != nil {
+		return fmt.Errorf("hadoop-snappy: determine xerial chunk decoded length: %w", err)
+	}
+
+	if decompressedLength > r.cfg.maxFrameSize {
+		return fmt.Errorf("hadoop-snappy: decompress xerial chunk: %w", ErrFrameTooLarge)
+	}
+
+	r.decompressedBuf.Reset()
+	r.decompressedBuf.Grow(decompressedLength)
+	dst := r.decompressedBuf.AvailableBuffer()[:decompressedLength]
+
+	decompressed, err := snappy.Decode(dst, r.compressedBuf.Bytes())
+	if err != nil {
+		return fmt.Errorf("hadoop-snappy: decompress xerial chunk: %w", err)
+	}
+
+	r.currentBlock = bytes.NewReader(decompressed)
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package snappy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	gsnappy "github.com/golang/snappy"
+)
+
+// buildXerialStream assembles a minimal xerial/snappy-java framed stream
+// out of the given chunks of uncompressed data.
+func buildXerialStream(t *testing.T, version, compatibleVersion int, chunks ...[]byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	buf.Write(xerialMagic)
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(version))
+	buf.Write(header[:])
+	binary.BigEndian.PutUint32(header[:], uint32(compatibleVersion))
+	buf.Write(header[:])
+
+	for _, chunk := range chunks {
+		encoded := gsnappy.Encode(nil, chunk)
+		binary.BigEndian.PutUint32(header[:], uint32(len(encoded)))
+		buf.Write(header[:])
+		buf.Write(encoded)
+	}
+
+	return buf.Bytes()
+}
+
+func TestNewAutoReaderDetectsXerialFormat(t *testing.T) {
+	input := buildXerialStream(t, 1, 1, []byte("hello, "), []byte("kafka!"))
+
+	r, err := NewAutoReader(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error from NewAutoReader: %v", err)
+	}
+
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error reading xerial stream: %v", err)
+	}
+
+	if want := "hello, kafka!"; string(output) != want {
+		t.Errorf("got %q, want %q", output, want)
+	}
+}
+
+func TestNewAutoReaderFallsBackToHadoopFormat(t *testing.T) {
+	// The same inline hadoop-snappy encoded "Hello, world!" used by Example.
+	input := []byte{0x00, 0x00, 0x00, 0x0D, 0x00, 0x00, 0x00, 0x0F, 0x0D, 0x30, 0x48, 0x65, 0x6C, 0x6C, 0x6F, 0x2C, 0x20, 0x77, 0x6F, 0x72, 0x6C, 0x64, 0x21}
+
+	r, err := NewAutoReader(bytes.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error from NewAutoReader: %v", err)
+	}
+
+	output, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error reading hadoop stream: %v", err)
+	}
+
+	if want := "Hello, world!"; string(output) != want {
+		t.Errorf("got %q, want %q", output, want)
+	}
+}
+
+func TestNewAutoReaderRejectsIncompatibleXerialVersion(t *testing.T) {
+	input := buildXerialStream(t, 2, 2, []byte("hello"))
+
+	_, err := NewAutoReader(bytes.NewReader(input))
+	if !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("got error %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestNewAutoReaderMaxBlockSizeOnXerialChunk(t *testing.T) {
+	input := buildXerialStream(t, 1, 1, []byte("hello, kafka!"))
+
+	r, err := NewAutoReader(bytes.NewReader(input), ReaderMaxBlockSize(1))
+	if err != nil {
+		t.Fatalf("unexpected error from NewAutoReader: %v", err)
+	}
+
+	_, err = io.ReadAll(r)
+	if !errors.Is(err, ErrBlockTooLarge) {
+		t.Fatalf("got error %v, want ErrBlockTooLarge", err)
+	}
+}
+
+func TestNewAutoReaderMaxFrameSizeOnXerialChunk(t *testing.T) {
+	input := buildXerialStream(t, 1, 1, []byte("hello, kafka!"))
+
+	r, err := NewAutoReader(bytes.NewReader(input), ReaderMaxFrameSize(1))
+	if err != nil {
+		t.Fatalf("unexpected error from NewAutoReader: %v", err)
+	}
+
+	_, err = io.ReadAll(r)
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("got error %v, want ErrFrameTooLarge", err)
+	}
+}